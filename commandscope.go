@@ -0,0 +1,156 @@
+package tgbot
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CommandScopeType is one of Telegram's BotCommandScope kinds.
+type CommandScopeType string
+
+const (
+	ScopeDefault               CommandScopeType = "default"
+	ScopeAllPrivateChats       CommandScopeType = "all_private_chats"
+	ScopeAllGroupChats         CommandScopeType = "all_group_chats"
+	ScopeAllChatAdministrators CommandScopeType = "all_chat_administrators"
+	ScopeChat                  CommandScopeType = "chat"
+	ScopeChatAdministrators    CommandScopeType = "chat_administrators"
+	ScopeChatMember            CommandScopeType = "chat_member"
+)
+
+// CommandScope narrows a Command to a subset of chats. The zero value is
+// ScopeDefault. ChatID and UserID only apply to the Chat*/ChatMember
+// scopes.
+type CommandScope struct {
+	Type   CommandScopeType
+	ChatID int64
+	UserID int64
+}
+
+// ScopeForChat targets a single chat.
+func ScopeForChat(chatID int64) CommandScope {
+	return CommandScope{Type: ScopeChat, ChatID: chatID}
+}
+
+// ScopeForChatAdministrators targets the administrators of a single chat.
+func ScopeForChatAdministrators(chatID int64) CommandScope {
+	return CommandScope{Type: ScopeChatAdministrators, ChatID: chatID}
+}
+
+// ScopeForChatMember targets a single member of a single chat.
+func ScopeForChatMember(chatID, userID int64) CommandScope {
+	return CommandScope{Type: ScopeChatMember, ChatID: chatID, UserID: userID}
+}
+
+// apiScope converts to the tgbotapi representation, returning nil for
+// ScopeDefault (and the zero value), which Telegram already applies when
+// no scope is sent.
+func (s CommandScope) apiScope() *tgbotapi.BotCommandScope {
+	if s.Type == "" || s.Type == ScopeDefault {
+		return nil
+	}
+	return &tgbotapi.BotCommandScope{
+		Type:   string(s.Type),
+		ChatID: s.ChatID,
+		UserID: s.UserID,
+	}
+}
+
+// specificity ranks scope types from most to least specific, mirroring
+// how Telegram itself resolves overlapping scopes.
+func (t CommandScopeType) specificity() int {
+	switch t {
+	case ScopeChatMember:
+		return 6
+	case ScopeChatAdministrators:
+		return 5
+	case ScopeChat:
+		return 4
+	case ScopeAllChatAdministrators:
+		return 3
+	case ScopeAllGroupChats, ScopeAllPrivateChats:
+		return 2
+	default: // ScopeDefault
+		return 1
+	}
+}
+
+// matches reports whether scope applies to the chat/user an update came
+// from. isAdmin is called lazily, and at most once, only for the scopes
+// that need it.
+func (s CommandScope) matches(chat *tgbotapi.Chat, user *tgbotapi.User, isAdmin func() bool) bool {
+	switch s.Type {
+	case ScopeChat:
+		return chat != nil && chat.ID == s.ChatID
+	case ScopeChatAdministrators:
+		return chat != nil && chat.ID == s.ChatID && isAdmin()
+	case ScopeChatMember:
+		return chat != nil && chat.ID == s.ChatID && user != nil && user.ID == s.UserID
+	case ScopeAllChatAdministrators:
+		return chat != nil && (chat.IsGroup() || chat.IsSuperGroup()) && isAdmin()
+	case ScopeAllGroupChats:
+		return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+	case ScopeAllPrivateChats:
+		return chat != nil && chat.IsPrivate()
+	default: // ScopeDefault
+		return true
+	}
+}
+
+// resolveCommand picks, among commands sharing a name, the one whose
+// scope/language best matches ctx's chat, falling back to ScopeDefault.
+func (bot *Bot) resolveCommand(ctx *Context, cmds []*Command) *Command {
+	chat, user := ctx.FromChat(), ctx.SentFrom()
+
+	lang := ""
+	if user != nil {
+		lang = user.LanguageCode
+	}
+
+	var adminChecked, isAdmin bool
+	admin := func() bool {
+		if !adminChecked {
+			isAdmin = bot.isChatAdmin(ctx, chat, user)
+			adminChecked = true
+		}
+		return isAdmin
+	}
+
+	var best *Command
+	for _, cmd := range cmds {
+		if cmd.LanguageCode != "" && cmd.LanguageCode != lang {
+			continue
+		}
+		if !cmd.Scope.matches(chat, user, admin) {
+			continue
+		}
+
+		switch {
+		case best == nil:
+			best = cmd
+		case cmd.Scope.Type.specificity() > best.Scope.Type.specificity():
+			best = cmd
+		case cmd.Scope.Type.specificity() == best.Scope.Type.specificity() && cmd.LanguageCode != "" && best.LanguageCode == "":
+			best = cmd
+		}
+	}
+
+	return best
+}
+
+func (bot *Bot) isChatAdmin(ctx *Context, chat *tgbotapi.Chat, user *tgbotapi.User) bool {
+	if chat == nil || user == nil {
+		return false
+	}
+
+	member, err := ctx.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chat.ID, UserID: user.ID},
+	})
+	if err != nil {
+		bot.errHandler(fmt.Errorf("failed to get chat member, error: %w", err))
+		return false
+	}
+
+	return member.IsAdministrator() || member.IsCreator()
+}
@@ -0,0 +1,53 @@
+package tgbot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestCommandScopeMatches(t *testing.T) {
+	chat := &tgbotapi.Chat{ID: 100, Type: "group"}
+	user := &tgbotapi.User{ID: 7}
+	notAdmin := func() bool { return false }
+	isAdmin := func() bool { return true }
+
+	scope := ScopeForChat(100)
+	if !scope.matches(chat, user, notAdmin) {
+		t.Fatal("expected ScopeForChat to match its own chat")
+	}
+	if scope.matches(&tgbotapi.Chat{ID: 200}, user, notAdmin) {
+		t.Fatal("expected ScopeForChat not to match a different chat")
+	}
+
+	admins := ScopeForChatAdministrators(100)
+	if admins.matches(chat, user, notAdmin) {
+		t.Fatal("expected ScopeChatAdministrators to require isAdmin")
+	}
+	if !admins.matches(chat, user, isAdmin) {
+		t.Fatal("expected ScopeChatAdministrators to match when isAdmin is true")
+	}
+}
+
+func TestCommandScopeTypeSpecificity(t *testing.T) {
+	if ScopeChatMember.specificity() <= ScopeChat.specificity() {
+		t.Fatal("expected ScopeChatMember to be more specific than ScopeChat")
+	}
+	if ScopeChat.specificity() <= ScopeAllGroupChats.specificity() {
+		t.Fatal("expected ScopeChat to be more specific than ScopeAllGroupChats")
+	}
+	if ScopeAllGroupChats.specificity() <= ScopeDefault.specificity() {
+		t.Fatal("expected ScopeAllGroupChats to be more specific than ScopeDefault")
+	}
+}
+
+func TestCommandScopeApiScope(t *testing.T) {
+	if s := (CommandScope{}).apiScope(); s != nil {
+		t.Fatal("expected the zero CommandScope to map to a nil apiScope")
+	}
+
+	got := ScopeForChatMember(100, 7).apiScope()
+	if got == nil || got.Type != string(ScopeChatMember) || got.ChatID != 100 || got.UserID != 7 {
+		t.Fatalf("unexpected apiScope: %+v", got)
+	}
+}
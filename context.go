@@ -14,6 +14,7 @@ type Context struct {
 	*tgbotapi.BotAPI
 
 	update *tgbotapi.Update
+	conv   *Conversation
 }
 
 func (ctx *Context) Command() string {
@@ -57,6 +58,37 @@ func (ctx *Context) FromChat() *tgbotapi.Chat {
 	return ctx.update.FromChat()
 }
 
+// SetState transitions the active Conversation to state s. It returns
+// ErrNoActiveConversation for handlers outside of a Conversation. Inside a
+// StateHandler, the State the handler returns is applied after it returns
+// and replaces s.
+func (ctx *Context) SetState(s State) error {
+	if ctx.conv == nil {
+		return ErrNoActiveConversation
+	}
+	return ctx.conv.setState(ctx.FromChat().ID, ctx.SentFrom().ID, s)
+}
+
+// EndConversation ends the active Conversation for this chat/user. Inside a
+// StateHandler, a non-empty State returned by the handler is stored
+// afterwards and starts the session again.
+func (ctx *Context) EndConversation() error {
+	if ctx.conv == nil {
+		return ErrNoActiveConversation
+	}
+	return ctx.conv.end(ctx.FromChat().ID, ctx.SentFrom().ID)
+}
+
+// ConversationData returns the typed key/value bag handlers use to
+// accumulate user input across the turns of the active Conversation. It
+// returns nil outside of a Conversation.
+func (ctx *Context) ConversationData() *DataBag {
+	if ctx.conv == nil {
+		return nil
+	}
+	return ctx.conv.dataBag(sessionKey{ctx.FromChat().ID, ctx.SentFrom().ID})
+}
+
 func (ctx *Context) ReplyText(text string, opts ...MessageConfigOption) error {
 	return ctx.reply(text, opts...)
 }
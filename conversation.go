@@ -0,0 +1,376 @@
+package tgbot
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State identifies one step of a Conversation.
+type State string
+
+// StateHandler processes an update while a user is in State and returns the
+// State to transition to next. Returning an empty State ends the
+// conversation.
+type StateHandler func(ctx *Context) (State, error)
+
+// ErrNoActiveConversation is returned by Context conversation helpers when
+// called outside of an active Conversation.
+var ErrNoActiveConversation = errors.New("tgbot: no active conversation")
+
+type sessionKey struct {
+	chatID int64
+	userID int64
+}
+
+// StateStore persists the current State of a Conversation per (chatID,
+// userID) pair, so conversations survive a process restart when backed by
+// something other than the in-memory default.
+type StateStore interface {
+	Get(chatID, userID int64) (State, bool, error)
+	Set(chatID, userID int64, state State) error
+	Delete(chatID, userID int64) error
+}
+
+// memoryStateStore is the default, non-durable StateStore.
+type memoryStateStore struct {
+	states sync.Map // sessionKey -> State
+}
+
+func newMemoryStateStore() *memoryStateStore {
+	return &memoryStateStore{}
+}
+
+func (s *memoryStateStore) Get(chatID, userID int64) (State, bool, error) {
+	v, ok := s.states.Load(sessionKey{chatID, userID})
+	if !ok {
+		return "", false, nil
+	}
+	return v.(State), true, nil
+}
+
+func (s *memoryStateStore) Set(chatID, userID int64, state State) error {
+	s.states.Store(sessionKey{chatID, userID}, state)
+	return nil
+}
+
+func (s *memoryStateStore) Delete(chatID, userID int64) error {
+	s.states.Delete(sessionKey{chatID, userID})
+	return nil
+}
+
+// DataBag is a typed key/value bag conversation handlers use to accumulate
+// user input across turns.
+type DataBag struct {
+	mu   sync.RWMutex
+	data map[string]interface{}
+}
+
+func newDataBag() *DataBag {
+	return &DataBag{data: make(map[string]interface{})}
+}
+
+func (d *DataBag) Get(key string) (interface{}, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	v, ok := d.data[key]
+	return v, ok
+}
+
+func (d *DataBag) Set(key string, value interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.data[key] = value
+}
+
+// Conversation is a finite-state machine driving a multi-turn flow, e.g.
+// /setname -> firstname -> lastname. Build one with NewConversation and
+// register it on a Bot via Bot.AddConversation.
+type Conversation struct {
+	name string
+
+	entries       map[string]State
+	states        map[State]StateHandler
+	fallback      StateHandler
+	timeout       time.Duration
+	stateTimeouts map[State]time.Duration
+
+	store StateStore
+
+	mu       sync.Mutex
+	data     map[sessionKey]*DataBag
+	lastSeen map[sessionKey]time.Time
+}
+
+// ConversationBuilder builds a Conversation.
+type ConversationBuilder struct {
+	conv *Conversation
+}
+
+// NewConversation starts building a Conversation identified by name, used
+// only for diagnostics.
+func NewConversation(name string) *ConversationBuilder {
+	return &ConversationBuilder{conv: &Conversation{
+		name:          name,
+		entries:       make(map[string]State),
+		states:        make(map[State]StateHandler),
+		stateTimeouts: make(map[State]time.Duration),
+		store:         newMemoryStateStore(),
+		data:          make(map[sessionKey]*DataBag),
+		lastSeen:      make(map[sessionKey]time.Time),
+	}}
+}
+
+// Entry registers a command that starts the conversation in the given
+// State.
+func (b *ConversationBuilder) Entry(command string, initial State) *ConversationBuilder {
+	b.conv.entries[command] = initial
+	return b
+}
+
+// State registers the StateHandler run while a user is in state s.
+func (b *ConversationBuilder) State(s State, h StateHandler) *ConversationBuilder {
+	b.conv.states[s] = h
+	return b
+}
+
+// StateTimeout overrides the default timeout for a specific state.
+func (b *ConversationBuilder) StateTimeout(s State, d time.Duration) *ConversationBuilder {
+	b.conv.stateTimeouts[s] = d
+	return b
+}
+
+// Fallback registers the handler run for a session whose current State has
+// no StateHandler registered. Updates in a State that has a StateHandler,
+// commands such as /cancel included, go to that StateHandler instead.
+func (b *ConversationBuilder) Fallback(h StateHandler) *ConversationBuilder {
+	b.conv.fallback = h
+	return b
+}
+
+// Timeout sets the default per-state idle timeout; a session idle past
+// this is dropped and the update falls through to normal dispatch.
+func (b *ConversationBuilder) Timeout(d time.Duration) *ConversationBuilder {
+	b.conv.timeout = d
+	return b
+}
+
+// StateStore overrides the default in-memory StateStore.
+func (b *ConversationBuilder) StateStore(store StateStore) *ConversationBuilder {
+	b.conv.store = store
+	return b
+}
+
+func (b *ConversationBuilder) Build() *Conversation {
+	return b.conv
+}
+
+func (c *Conversation) timeoutFor(s State) time.Duration {
+	if d, ok := c.stateTimeouts[s]; ok {
+		return d
+	}
+	return c.timeout
+}
+
+func (c *Conversation) touch(key sessionKey) {
+	c.mu.Lock()
+	c.lastSeen[key] = time.Now()
+	c.mu.Unlock()
+}
+
+func (c *Conversation) expired(key sessionKey, state State) bool {
+	timeout := c.timeoutFor(state)
+	if timeout <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	last, ok := c.lastSeen[key]
+	c.mu.Unlock()
+	return ok && time.Since(last) > timeout
+}
+
+// reapExpired ends every session that has been idle past its state's
+// timeout. Without this, a session abandoned mid-conversation (the user
+// never sends another message) would otherwise sit in c.data and
+// c.lastSeen forever, since expiry is normally only checked when a new
+// update for that session arrives.
+func (c *Conversation) reapExpired(errHandler ErrHandler) {
+	c.mu.Lock()
+	keys := make([]sessionKey, 0, len(c.lastSeen))
+	for key := range c.lastSeen {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	for _, key := range keys {
+		state, ok, err := c.store.Get(key.chatID, key.userID)
+		if err != nil {
+			errHandler(err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if !c.expired(key, state) {
+			continue
+		}
+		if err := c.end(key.chatID, key.userID); err != nil {
+			errHandler(err)
+		}
+	}
+}
+
+func (c *Conversation) dataBag(key sessionKey) *DataBag {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bag, ok := c.data[key]
+	if !ok {
+		bag = newDataBag()
+		c.data[key] = bag
+	}
+	return bag
+}
+
+func (c *Conversation) setState(chatID, userID int64, state State) error {
+	key := sessionKey{chatID, userID}
+	c.touch(key)
+	return c.store.Set(chatID, userID, state)
+}
+
+func (c *Conversation) end(chatID, userID int64) error {
+	key := sessionKey{chatID, userID}
+	c.mu.Lock()
+	delete(c.data, key)
+	delete(c.lastSeen, key)
+	c.mu.Unlock()
+	return c.store.Delete(chatID, userID)
+}
+
+// AddConversation registers a Conversation on the Bot.
+func (bot *Bot) AddConversation(c *Conversation) {
+	bot.conversations = append(bot.conversations, c)
+}
+
+// reapInterval is how often startConversationReaper sweeps for expired
+// conversation sessions.
+const reapInterval = time.Minute
+
+// startConversationReaper periodically sweeps every registered
+// Conversation for idle sessions past their timeout, so an abandoned
+// conversation doesn't hold its DataBag and state forever.
+func (bot *Bot) startConversationReaper() {
+	if len(bot.conversations) == 0 {
+		return
+	}
+
+	bot.wg.Add(1)
+	go func() {
+		defer bot.wg.Done()
+
+		ticker := time.NewTicker(reapInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-bot.ctx.Done():
+				return
+			case <-ticker.C:
+				for _, conv := range bot.conversations {
+					conv.reapExpired(bot.errHandler)
+				}
+			}
+		}
+	}()
+}
+
+// dispatchConversation routes ctx to the active Conversation for its
+// (chat, user), either resuming an in-progress one or starting a new one
+// via a matched entry command. It returns true if the update was handled.
+func (bot *Bot) dispatchConversation(ctx *Context) bool {
+	chat, user := ctx.FromChat(), ctx.SentFrom()
+	if chat == nil || user == nil {
+		return false
+	}
+	key := sessionKey{chat.ID, user.ID}
+
+	for _, conv := range bot.conversations {
+		state, ok, err := conv.store.Get(chat.ID, user.ID)
+		if err != nil {
+			bot.errHandler(err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if conv.expired(key, state) {
+			if err := conv.end(chat.ID, user.ID); err != nil {
+				bot.errHandler(err)
+			}
+			continue
+		}
+
+		handler, ok := conv.states[state]
+		if !ok {
+			handler = conv.fallback
+		}
+		if handler == nil {
+			continue
+		}
+
+		bot.runConversationStep(ctx, conv, handler)
+		return true
+	}
+
+	if !(ctx.update.Message != nil && ctx.update.Message.IsCommand()) {
+		return false
+	}
+
+	for _, conv := range bot.conversations {
+		initial, ok := conv.entries[ctx.Command()]
+		if !ok {
+			continue
+		}
+		handler, ok := conv.states[initial]
+		if !ok {
+			continue
+		}
+		bot.runConversationStep(ctx, conv, handler)
+		return true
+	}
+
+	return false
+}
+
+func (bot *Bot) runConversationStep(ctx *Context, conv *Conversation, handler StateHandler) {
+	key := sessionKey{ctx.FromChat().ID, ctx.SentFrom().ID}
+	conv.touch(key)
+
+	ctx.conv = conv
+
+	// Run the StateHandler through the same global middleware chain as
+	// command and filter handlers, capturing the next State in a
+	// closure since Handler's signature has no room for one.
+	var next State
+	wrapped := chain(func(ctx *Context) error {
+		var err error
+		next, err = handler(ctx)
+		return err
+	}, bot.middlewares...)
+
+	if err := wrapped(ctx); err != nil {
+		bot.errHandler(err)
+		return
+	}
+
+	if next == "" {
+		if err := conv.end(ctx.FromChat().ID, ctx.SentFrom().ID); err != nil {
+			bot.errHandler(err)
+		}
+		return
+	}
+
+	if err := conv.setState(ctx.FromChat().ID, ctx.SentFrom().ID, next); err != nil {
+		bot.errHandler(err)
+	}
+}
@@ -0,0 +1,58 @@
+package tgbot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConversationTimeoutFor(t *testing.T) {
+	conv := NewConversation("test").
+		Timeout(time.Minute).
+		StateTimeout("special", 5*time.Second).
+		Build()
+
+	if d := conv.timeoutFor("normal"); d != time.Minute {
+		t.Fatalf("expected the default timeout, got %v", d)
+	}
+	if d := conv.timeoutFor("special"); d != 5*time.Second {
+		t.Fatalf("expected the per-state override, got %v", d)
+	}
+}
+
+func TestConversationExpired(t *testing.T) {
+	conv := NewConversation("test").Timeout(10 * time.Millisecond).Build()
+	key := sessionKey{chatID: 1, userID: 1}
+
+	conv.touch(key)
+	if conv.expired(key, "state") {
+		t.Fatal("expected a freshly touched session not to be expired")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if !conv.expired(key, "state") {
+		t.Fatal("expected the session to be expired after its timeout elapsed")
+	}
+}
+
+func TestConversationReapExpired(t *testing.T) {
+	conv := NewConversation("test").
+		Entry("/start", "waiting").
+		State("waiting", func(ctx *Context) (State, error) { return "", nil }).
+		Timeout(10 * time.Millisecond).
+		Build()
+
+	if err := conv.setState(1, 1, "waiting"); err != nil {
+		t.Fatal(err)
+	}
+	conv.dataBag(sessionKey{chatID: 1, userID: 1}).Set("k", "v")
+
+	time.Sleep(20 * time.Millisecond)
+	conv.reapExpired(func(err error) { t.Fatal(err) })
+
+	if _, ok, _ := conv.store.Get(1, 1); ok {
+		t.Fatal("expected the expired session's state to be removed")
+	}
+	if _, ok := conv.data[sessionKey{chatID: 1, userID: 1}]; ok {
+		t.Fatal("expected the expired session's DataBag to be removed")
+	}
+}
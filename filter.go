@@ -0,0 +1,64 @@
+package tgbot
+
+import (
+	"regexp"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Filter decides whether an update should be routed to a Handler registered
+// via Bot.Handle.
+type Filter interface {
+	Match(update *tgbotapi.Update) bool
+}
+
+// FilterFunc adapts a plain function to a Filter.
+type FilterFunc func(update *tgbotapi.Update) bool
+
+func (f FilterFunc) Match(update *tgbotapi.Update) bool {
+	return f(update)
+}
+
+// OnText matches non-command text messages.
+var OnText Filter = FilterFunc(func(update *tgbotapi.Update) bool {
+	return update.Message != nil && update.Message.Text != "" && !update.Message.IsCommand()
+})
+
+// OnPhoto matches messages carrying a photo.
+var OnPhoto Filter = FilterFunc(func(update *tgbotapi.Update) bool {
+	return update.Message != nil && len(update.Message.Photo) > 0
+})
+
+// OnCallbackQuery matches callback query updates, e.g. inline keyboard taps.
+var OnCallbackQuery Filter = FilterFunc(func(update *tgbotapi.Update) bool {
+	return update.CallbackQuery != nil
+})
+
+// OnInlineQuery matches inline query updates.
+var OnInlineQuery Filter = FilterFunc(func(update *tgbotapi.Update) bool {
+	return update.InlineQuery != nil
+})
+
+// OnChatMember matches chat member status updates.
+var OnChatMember Filter = FilterFunc(func(update *tgbotapi.Update) bool {
+	return update.ChatMember != nil
+})
+
+// OnEditedMessage matches edits of previously sent messages.
+var OnEditedMessage Filter = FilterFunc(func(update *tgbotapi.Update) bool {
+	return update.EditedMessage != nil
+})
+
+// OnChannelPost matches posts published to a channel.
+var OnChannelPost Filter = FilterFunc(func(update *tgbotapi.Update) bool {
+	return update.ChannelPost != nil
+})
+
+// OnRegex matches text messages whose text matches pattern. It panics if
+// pattern fails to compile, mirroring regexp.MustCompile.
+func OnRegex(pattern string) Filter {
+	re := regexp.MustCompile(pattern)
+	return FilterFunc(func(update *tgbotapi.Update) bool {
+		return update.Message != nil && re.MatchString(update.Message.Text)
+	})
+}
@@ -0,0 +1,47 @@
+package tgbot
+
+// Middleware wraps a Handler to add cross-cutting behaviour (logging, auth,
+// rate-limiting, i18n, ...) around it.
+type Middleware func(Handler) Handler
+
+// Use registers global middlewares, applied to every command and filter
+// handler in the order they were registered.
+func (bot *Bot) Use(mw ...Middleware) {
+	bot.middlewares = append(bot.middlewares, mw...)
+}
+
+// chain wraps h with mws so that mws[0] runs outermost.
+func chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+type filterEntry struct {
+	filter  Filter
+	handler Handler
+}
+
+// Handle registers a Handler that runs for updates matched by filter.
+// Filters are tried in registration order; the first match wins. When no
+// filter matches, bot.updatesHandler is used as the fallback.
+func (bot *Bot) Handle(filter Filter, h Handler) {
+	bot.filters = append(bot.filters, filterEntry{filter: filter, handler: h})
+}
+
+// dispatchFilter runs the handler of the first registered filter that
+// matches the update, returning true if one matched.
+func (bot *Bot) dispatchFilter(ctx *Context) bool {
+	for _, e := range bot.filters {
+		if !e.filter.Match(ctx.update) {
+			continue
+		}
+		handler := chain(e.handler, bot.middlewares...)
+		if err := handler(ctx); err != nil {
+			bot.errHandler(err)
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package tgbot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx *Context) error {
+				order = append(order, name)
+				return next(ctx)
+			}
+		}
+	}
+
+	h := chain(func(ctx *Context) error {
+		order = append(order, "handler")
+		return nil
+	}, record("outer"), record("inner"))
+
+	if err := h(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"outer", "inner", "handler"}
+	if len(order) != len(want) {
+		t.Fatalf("got %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got %v, want %v", order, want)
+		}
+	}
+}
+
+func TestOnRegex(t *testing.T) {
+	f := OnRegex(`^/greet \w+$`)
+
+	match := &tgbotapi.Update{Message: &tgbotapi.Message{Text: "/greet alice"}}
+	noMatch := &tgbotapi.Update{Message: &tgbotapi.Message{Text: "hello"}}
+
+	if !f.Match(match) {
+		t.Fatal("expected OnRegex to match")
+	}
+	if f.Match(noMatch) {
+		t.Fatal("expected OnRegex not to match")
+	}
+}
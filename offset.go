@@ -0,0 +1,156 @@
+package tgbot
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OffsetStore persists the getUpdates offset so a restarted bot can resume
+// without re-delivering already-handled updates or losing updates it
+// never got to.
+type OffsetStore interface {
+	Load() (int, error)
+	Save(offset int) error
+}
+
+// AckMode controls when pollUpdates persists the offset to the
+// OffsetStore.
+type AckMode int
+
+const (
+	// AckOnDispatch persists the offset as soon as an update is handed
+	// off to a worker. Simple and cheap, but a crash mid-processing
+	// re-delivers that update on resume. This is the default.
+	AckOnDispatch AckMode = iota
+	// AckOnComplete persists the offset only once an update has finished
+	// processing, advanced to the lowest UpdateID still in flight. A
+	// crash re-delivers at most the updates that were still being
+	// processed.
+	AckOnComplete
+)
+
+// memoryOffsetStore is the default, non-durable OffsetStore.
+type memoryOffsetStore struct {
+	mu     sync.Mutex
+	offset int
+}
+
+func newMemoryOffsetStore() *memoryOffsetStore {
+	return &memoryOffsetStore{}
+}
+
+func (s *memoryOffsetStore) Load() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offset, nil
+}
+
+func (s *memoryOffsetStore) Save(offset int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset = offset
+	return nil
+}
+
+// FileOffsetStore persists the offset as plain text in a file, so it
+// survives a process restart.
+type FileOffsetStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileOffsetStore returns an OffsetStore backed by the file at path. The
+// file is created on first Save and may not exist yet when passed to
+// WithOffsetStore.
+func NewFileOffsetStore(path string) *FileOffsetStore {
+	return &FileOffsetStore{path: path}
+}
+
+func (s *FileOffsetStore) Load() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(text)
+}
+
+func (s *FileOffsetStore) Save(offset int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write to a temp file and rename over s.path so a crash or power
+	// loss mid-write can never leave a truncated, unparsable offset
+	// file behind.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.Itoa(offset)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// offsetTracker derives the AckOnComplete offset: the lowest UpdateID
+// still being processed (or, with none in flight, one past the highest
+// UpdateID seen), so completed-but-out-of-order updates don't advance the
+// persisted offset past ones that haven't finished yet.
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending map[int]struct{}
+	next    int // one past the highest UpdateID seen
+	store   OffsetStore
+}
+
+func newOffsetTracker(store OffsetStore) *offsetTracker {
+	return &offsetTracker{pending: make(map[int]struct{}), store: store}
+}
+
+func (t *offsetTracker) markDispatched(updateID int) {
+	t.mu.Lock()
+	t.pending[updateID] = struct{}{}
+	if updateID+1 > t.next {
+		t.next = updateID + 1
+	}
+	t.mu.Unlock()
+}
+
+func (t *offsetTracker) markComplete(updateID int) error {
+	t.mu.Lock()
+	delete(t.pending, updateID)
+	if updateID+1 > t.next {
+		t.next = updateID + 1
+	}
+
+	lowest := t.next
+	for id := range t.pending {
+		if id < lowest {
+			lowest = id
+		}
+	}
+	t.mu.Unlock()
+
+	return t.store.Save(lowest)
+}
@@ -0,0 +1,55 @@
+package tgbot
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOffsetTrackerMarkComplete(t *testing.T) {
+	store := newMemoryOffsetStore()
+	tr := newOffsetTracker(store)
+
+	tr.markDispatched(1)
+	tr.markDispatched(2)
+	tr.markDispatched(3)
+
+	// 2 finishes first, but 1 is still pending, so the persisted offset
+	// must not advance past it.
+	if err := tr.markComplete(2); err != nil {
+		t.Fatal(err)
+	}
+	if off, _ := store.Load(); off != 1 {
+		t.Fatalf("expected offset to stay at the lowest pending update (1), got %d", off)
+	}
+
+	if err := tr.markComplete(1); err != nil {
+		t.Fatal(err)
+	}
+	if off, _ := store.Load(); off != 3 {
+		t.Fatalf("expected offset to advance to the lowest pending update (3), got %d", off)
+	}
+
+	if err := tr.markComplete(3); err != nil {
+		t.Fatal(err)
+	}
+	if off, _ := store.Load(); off != 4 {
+		t.Fatalf("expected offset to advance past the last completed update, got %d", off)
+	}
+}
+
+func TestFileOffsetStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offset")
+	store := NewFileOffsetStore(path)
+
+	if off, err := store.Load(); err != nil || off != 0 {
+		t.Fatalf("expected a missing offset file to load as 0, got %d, %v", off, err)
+	}
+
+	if err := store.Save(42); err != nil {
+		t.Fatal(err)
+	}
+
+	if off, err := store.Load(); err != nil || off != 42 {
+		t.Fatalf("expected the saved offset to round-trip, got %d, %v", off, err)
+	}
+}
@@ -106,3 +106,71 @@ func WithContext(ctx context.Context) Option {
 		b.ctx = ctx
 	}
 }
+
+// WithWebhook makes the bot receive updates via an HTTP webhook instead of
+// long polling, see WebhookConfig.
+func WithWebhook(cfg WebhookConfig) Option {
+	return func(b *Bot) {
+		b.updateSource = &WebhookSource{bot: b, cfg: cfg}
+	}
+}
+
+// WithUpdateSource overrides how the bot obtains updates, see UpdateSource.
+func WithUpdateSource(src UpdateSource) Option {
+	return func(b *Bot) {
+		b.updateSource = src
+	}
+}
+
+// WithRateLimiter enables rate limiting via rl. Pair with WithThrottleMode
+// to control what happens to a denied update; the default is to drop it.
+func WithRateLimiter(rl RateLimiter) Option {
+	return func(b *Bot) {
+		b.rateLimiter = rl
+	}
+}
+
+// WithThrottleMode sets what happens to an update denied by the
+// RateLimiter.
+func WithThrottleMode(mode ThrottleMode) Option {
+	return func(b *Bot) {
+		b.throttleMode = mode
+	}
+}
+
+// WithThrottleMessage sets the text replied to a user whose update was
+// denied under ThrottleReply.
+func WithThrottleMessage(message string) Option {
+	return func(b *Bot) {
+		b.throttleMessage = message
+	}
+}
+
+// WithOffsetStore makes the bot resume the getUpdates offset from store
+// across restarts, instead of always starting from 0. See also
+// WithAckMode.
+func WithOffsetStore(store OffsetStore) Option {
+	return func(b *Bot) {
+		b.offsetStore = store
+	}
+}
+
+// WithAckMode sets when the offset is persisted to the OffsetStore; see
+// AckMode.
+func WithAckMode(mode AckMode) Option {
+	return func(b *Bot) {
+		b.ackMode = mode
+	}
+}
+
+// WithChatSerialization makes the bot process updates from the same chat
+// in order, by hashing chat IDs onto n ordered sub-queues. n <= 0 defaults
+// to WithWorkerNum's value. This clears any WithWorkerPool, since a pool
+// gives no ordering guarantee across its goroutines, which would defeat
+// the point of serializing per chat.
+func WithChatSerialization(n int) Option {
+	return func(b *Bot) {
+		b.serializeChats = true
+		b.numSubQueues = n
+	}
+}
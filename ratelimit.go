@@ -0,0 +1,173 @@
+package tgbot
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter decides whether an update from (chatID, userID) may be
+// processed right now. When it returns false, the returned duration is how
+// long the caller should wait before the request would be allowed.
+type RateLimiter interface {
+	Allow(chatID, userID int64) (bool, time.Duration)
+}
+
+// ThrottleMode controls what happens to an update denied by the
+// RateLimiter.
+type ThrottleMode int
+
+const (
+	// ThrottleDrop silently discards the update. This is the default.
+	ThrottleDrop ThrottleMode = iota
+	// ThrottleReply replies with the bot's configured throttle message
+	// and discards the update.
+	ThrottleReply
+	// ThrottleDelay waits out the RateLimiter's suggested delay and then
+	// processes the update as usual.
+	ThrottleDelay
+)
+
+// checkRateLimit applies bot.rateLimiter, if any, to ctx's update. It
+// returns true if processing should proceed.
+func (bot *Bot) checkRateLimit(ctx *Context) bool {
+	if bot.rateLimiter == nil {
+		return true
+	}
+
+	chat := ctx.FromChat()
+	if chat == nil {
+		return true
+	}
+
+	var userID int64
+	if user := ctx.SentFrom(); user != nil {
+		userID = user.ID
+	}
+
+	ok, wait := bot.rateLimiter.Allow(chat.ID, userID)
+	if ok {
+		return true
+	}
+
+	switch bot.throttleMode {
+	case ThrottleDelay:
+		// Wait out the bucket's suggested delay, then re-check: the
+		// wait is only an estimate, and other requests may have
+		// consumed the token that would have opened up by then.
+		for {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-bot.ctx.Done():
+				timer.Stop()
+				return false
+			}
+
+			var ok bool
+			if ok, wait = bot.rateLimiter.Allow(chat.ID, userID); ok {
+				return true
+			}
+		}
+
+	case ThrottleReply:
+		if bot.throttleMessage != "" {
+			if err := ctx.ReplyText(bot.throttleMessage); err != nil {
+				bot.errHandler(err)
+			}
+		}
+		return false
+
+	default: // ThrottleDrop
+		return false
+	}
+}
+
+// bucket is a single token bucket.
+type bucket struct {
+	mu sync.Mutex
+
+	capacity float64
+	rate     float64 // tokens replenished per second
+
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(capacity, ratePerSecond float64) *bucket {
+	return &bucket{capacity: capacity, rate: ratePerSecond, tokens: capacity, last: time.Now()}
+}
+
+func (b *bucket) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, wait
+}
+
+// BucketConfig configures one token bucket: Burst tokens, replenished at
+// RatePerSecond.
+type BucketConfig struct {
+	Burst         float64
+	RatePerSecond float64
+}
+
+// TokenBucketLimiter is a RateLimiter backed by token buckets. Any of
+// Global, PerChat and PerUser may be left zero-valued to skip that layer.
+type TokenBucketLimiter struct {
+	global *bucket
+
+	perChatCfg BucketConfig
+	perUserCfg BucketConfig
+
+	perChat sync.Map // chatID -> *bucket
+	perUser sync.Map // userID -> *bucket
+}
+
+// NewTokenBucketLimiter builds a TokenBucketLimiter. A zero-valued
+// BucketConfig disables that layer.
+func NewTokenBucketLimiter(global, perChat, perUser BucketConfig) *TokenBucketLimiter {
+	l := &TokenBucketLimiter{perChatCfg: perChat, perUserCfg: perUser}
+	if global.RatePerSecond > 0 {
+		l.global = newBucket(global.Burst, global.RatePerSecond)
+	}
+	return l
+}
+
+func (l *TokenBucketLimiter) Allow(chatID, userID int64) (bool, time.Duration) {
+	if l.global != nil {
+		if ok, wait := l.global.allow(); !ok {
+			return false, wait
+		}
+	}
+
+	if l.perChatCfg.RatePerSecond > 0 {
+		b, _ := l.perChat.LoadOrStore(chatID, newBucket(l.perChatCfg.Burst, l.perChatCfg.RatePerSecond))
+		if ok, wait := b.(*bucket).allow(); !ok {
+			return false, wait
+		}
+	}
+
+	if l.perUserCfg.RatePerSecond > 0 {
+		b, _ := l.perUser.LoadOrStore(userID, newBucket(l.perUserCfg.Burst, l.perUserCfg.RatePerSecond))
+		if ok, wait := b.(*bucket).allow(); !ok {
+			return false, wait
+		}
+	}
+
+	return true, 0
+}
@@ -0,0 +1,51 @@
+package tgbot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketAllow(t *testing.T) {
+	b := newBucket(2, 1) // burst of 2, refill 1/sec
+
+	if ok, _ := b.allow(); !ok {
+		t.Fatal("expected first token to be available")
+	}
+	if ok, _ := b.allow(); !ok {
+		t.Fatal("expected second token to be available")
+	}
+	if ok, wait := b.allow(); ok {
+		t.Fatal("expected bucket to be empty")
+	} else if wait <= 0 {
+		t.Fatalf("expected a positive wait, got %v", wait)
+	}
+}
+
+func TestBucketRefill(t *testing.T) {
+	b := newBucket(1, 1000) // refill fast enough to observe in a test
+	b.tokens = 0            // start empty, so only a refill can make a token available
+	b.last = time.Now().Add(-time.Second)
+
+	ok, _ := b.allow()
+	if !ok {
+		t.Fatal("expected bucket to have refilled after 1s at 1000/sec")
+	}
+}
+
+func TestTokenBucketLimiterLayers(t *testing.T) {
+	l := NewTokenBucketLimiter(
+		BucketConfig{},                           // no global cap
+		BucketConfig{Burst: 1, RatePerSecond: 1}, // per-chat
+		BucketConfig{},                           // no per-user cap
+	)
+
+	if ok, _ := l.Allow(1, 1); !ok {
+		t.Fatal("expected first request for chat 1 to be allowed")
+	}
+	if ok, _ := l.Allow(1, 2); ok {
+		t.Fatal("expected second request for the same chat to be denied regardless of user")
+	}
+	if ok, _ := l.Allow(2, 1); !ok {
+		t.Fatal("expected a different chat to have its own bucket")
+	}
+}
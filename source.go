@@ -0,0 +1,26 @@
+package tgbot
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// UpdateSource produces the stream of updates a Bot dispatches to workers.
+// Start returns the channel updates are delivered on. Workers stop reading
+// when ctx is cancelled or the channel is closed; the built-in sources
+// never close theirs.
+type UpdateSource interface {
+	Start(ctx context.Context) (<-chan *tgbotapi.Update, error)
+}
+
+// longPollSource is the default UpdateSource, backed by repeated
+// getUpdates calls.
+type longPollSource struct {
+	bot *Bot
+}
+
+func (s *longPollSource) Start(ctx context.Context) (<-chan *tgbotapi.Update, error) {
+	go s.bot.pollUpdates()
+	return s.bot.updateC, nil
+}
@@ -18,6 +18,14 @@ type Command struct {
 	Description string
 	Hide        bool // hide the command on telegram commands menu
 	Handler     Handler
+	Middlewares []Middleware // middlewares applied only to this command, after the global ones
+
+	// Scope and LanguageCode narrow when this Command applies, letting
+	// the same Name be registered multiple times for different chat
+	// scopes and/or languages. The zero Scope is ScopeDefault and the
+	// zero LanguageCode applies to every language.
+	Scope        CommandScope
+	LanguageCode string
 }
 
 func (c Command) String() string {
@@ -45,7 +53,11 @@ type Bot struct {
 	cancel context.CancelFunc
 
 	commands    []*Command
-	cmdHandlers map[string]Handler
+	cmdHandlers map[string][]*Command
+
+	middlewares   []Middleware
+	filters       []filterEntry
+	conversations []*Conversation
 
 	timeout                 time.Duration
 	undefinedCommandHandler Handler
@@ -64,6 +76,19 @@ type Bot struct {
 	limit          int
 	offset         int
 	allowedUpdates []string
+
+	offsetStore   OffsetStore
+	ackMode       AckMode
+	offsetTracker *offsetTracker
+
+	updateSource UpdateSource
+
+	rateLimiter     RateLimiter
+	throttleMode    ThrottleMode
+	throttleMessage string
+
+	serializeChats bool
+	numSubQueues   int
 }
 
 func NewBot(api *tgbotapi.BotAPI, opts ...Option) *Bot {
@@ -72,7 +97,7 @@ func NewBot(api *tgbotapi.BotAPI, opts ...Option) *Bot {
 
 		api: api,
 
-		cmdHandlers: make(map[string]Handler),
+		cmdHandlers: make(map[string][]*Command),
 		errHandler:  func(err error) {},
 
 		workerNum: runtime.GOMAXPROCS(0),
@@ -92,6 +117,17 @@ func NewBot(api *tgbotapi.BotAPI, opts ...Option) *Bot {
 		o(bot)
 	}
 
+	if bot.offsetStore == nil {
+		bot.offsetStore = newMemoryOffsetStore()
+	}
+	bot.offsetTracker = newOffsetTracker(bot.offsetStore)
+
+	if bot.serializeChats {
+		// An ants pool gives no ordering guarantee across its
+		// goroutines, which would defeat per-chat serialization.
+		bot.workerPool = nil
+	}
+
 	bot.ctx, bot.cancel = context.WithCancel(bot.ctx)
 
 	// hijack the api client
@@ -118,7 +154,7 @@ func (bot *Bot) allocateContext() *Context {
 
 func (bot *Bot) AddCommand(cmd *Command) {
 	bot.commands = append(bot.commands, cmd)
-	bot.cmdHandlers[cmd.Name] = cmd.Handler
+	bot.cmdHandlers[cmd.Name] = append(bot.cmdHandlers[cmd.Name], cmd)
 }
 
 func (bot *Bot) Commands() []*Command {
@@ -131,22 +167,45 @@ func (bot *Bot) Commands() []*Command {
 	return commands
 }
 
+// commandGroupKey groups commands that share a (scope, language) pair, so
+// they can be pushed to Telegram with a single SetMyCommands call.
+type commandGroupKey struct {
+	scope CommandScope
+	lang  string
+}
+
 func (bot *Bot) setupCommands() error {
-	commands := make([]tgbotapi.BotCommand, 0, len(bot.commands))
-	for _, hdr := range bot.Commands() {
-		commands = append(commands, tgbotapi.BotCommand{
-			Command:     hdr.Name,
-			Description: hdr.Description,
+	var order []commandGroupKey
+	groups := make(map[commandGroupKey][]tgbotapi.BotCommand)
+
+	for _, cmd := range bot.Commands() {
+		key := commandGroupKey{scope: cmd.Scope, lang: cmd.LanguageCode}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], tgbotapi.BotCommand{
+			Command:     cmd.Name,
+			Description: cmd.Description,
 		})
 	}
 
-	_, err := bot.api.Request(tgbotapi.NewSetMyCommands(commands...))
-	return err
+	for _, key := range order {
+		cfg := tgbotapi.NewSetMyCommands(groups[key]...)
+		cfg.LanguageCode = key.lang
+		cfg.Scope = key.scope.apiScope()
+
+		if _, err := bot.api.Request(cfg); err != nil {
+			return fmt.Errorf("failed to set commands for scope %q, error: %w", key.scope.Type, err)
+		}
+	}
+
+	return nil
 }
 
 func (bot *Bot) handleUpdate(update *tgbotapi.Update) {
 	ctx := bot.allocateContext()
 	ctx.update = update
+	ctx.conv = nil // clear any Conversation left over from this pooled Context's last use
 
 	if bot.workerPool == nil || bot.panicHandler != nil {
 		defer func() {
@@ -161,6 +220,19 @@ func (bot *Bot) handleUpdate(update *tgbotapi.Update) {
 	}
 
 	executeHandler := func() {
+		if bot.ackMode == AckOnComplete {
+			defer func() {
+				if err := bot.offsetTracker.markComplete(update.UpdateID); err != nil {
+					bot.errHandler(fmt.Errorf("failed to save offset, error: %w", err))
+				}
+			}()
+		}
+
+		if !bot.checkRateLimit(ctx) {
+			ctx.put()
+			return
+		}
+
 		if bot.timeout > 0 {
 			var cancel context.CancelFunc
 			ctx.Context, cancel = context.WithTimeout(ctx.Context, bot.timeout)
@@ -168,9 +240,15 @@ func (bot *Bot) handleUpdate(update *tgbotapi.Update) {
 		}
 
 		switch {
+		case bot.dispatchConversation(ctx):
+			// handled by the active or newly entered conversation's StateHandler
+
 		case update.Message != nil && update.Message.IsCommand():
 			bot.executeCommandHandler(ctx)
 
+		case bot.dispatchFilter(ctx):
+			// handled by the matched filter's handler
+
 		default:
 			bot.executeUpdatesHandler(ctx)
 		}
@@ -182,15 +260,20 @@ func (bot *Bot) handleUpdate(update *tgbotapi.Update) {
 		if err := bot.workerPool.Submit(executeHandler); err != nil {
 			bot.errHandler(err)
 		}
+		return
 	}
 
 	executeHandler()
 }
 
 func (bot *Bot) executeCommandHandler(ctx *Context) {
-	handler, ok := bot.cmdHandlers[ctx.Command()]
-	if !ok {
+	cmd := bot.resolveCommand(ctx, bot.cmdHandlers[ctx.Command()])
+
+	var handler Handler
+	if cmd == nil {
 		handler = bot.undefinedCmdHandler
+	} else {
+		handler = chain(cmd.Handler, append(append([]Middleware{}, bot.middlewares...), cmd.Middlewares...)...)
 	}
 
 	if err := handler(ctx); err != nil {
@@ -213,7 +296,7 @@ func (bot *Bot) undefinedCmdHandler(ctx *Context) error {
 	return ctx.ReplyText("Unrecognized command!!!")
 }
 
-func (bot *Bot) startWorkers() {
+func (bot *Bot) startWorkers(updateC <-chan *tgbotapi.Update) {
 	startWorker := func() {
 		defer bot.wg.Done()
 
@@ -222,7 +305,10 @@ func (bot *Bot) startWorkers() {
 			case <-bot.ctx.Done():
 				return
 
-			case update := <-bot.updateC:
+			case update, ok := <-updateC:
+				if !ok {
+					return
+				}
 				bot.handleUpdate(update)
 			}
 		}
@@ -234,6 +320,73 @@ func (bot *Bot) startWorkers() {
 	}
 }
 
+// startSerializedWorkers hashes each update's chat ID onto a fixed set of
+// ordered sub-queues, each drained by a single goroutine, so updates from
+// the same chat are always handled in arrival order even though the bot
+// as a whole processes many chats concurrently.
+func (bot *Bot) startSerializedWorkers(updateC <-chan *tgbotapi.Update) {
+	n := bot.numSubQueues
+	if n <= 0 {
+		n = bot.workerNum
+	}
+
+	queues := make([]chan *tgbotapi.Update, n)
+	for i := range queues {
+		queues[i] = make(chan *tgbotapi.Update, bot.bufSize)
+
+		bot.wg.Add(1)
+		go func(q chan *tgbotapi.Update) {
+			defer bot.wg.Done()
+			for {
+				select {
+				case <-bot.ctx.Done():
+					return
+
+				case update, ok := <-q:
+					if !ok {
+						return
+					}
+					bot.handleUpdate(update)
+				}
+			}
+		}(queues[i])
+	}
+
+	bot.wg.Add(1)
+	go func() {
+		defer bot.wg.Done()
+		for {
+			select {
+			case <-bot.ctx.Done():
+				return
+
+			case update, ok := <-updateC:
+				if !ok {
+					return
+				}
+
+				q := queues[chatQueueIndex(update, n)]
+				select {
+				case q <- update:
+				case <-bot.ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+}
+
+// chatQueueIndex hashes an update's chat ID onto one of n sub-queues.
+// Updates with no chat (e.g. poll answers with no chat context) all land
+// on queue 0.
+func chatQueueIndex(update *tgbotapi.Update, n int) int {
+	chat := update.FromChat()
+	if chat == nil {
+		return 0
+	}
+	return int(uint64(chat.ID) % uint64(n))
+}
+
 func (bot *Bot) pollUpdates() {
 	for {
 		select {
@@ -256,25 +409,58 @@ func (bot *Bot) pollUpdates() {
 		}
 
 		for _, update := range updates {
-			if update.UpdateID >= bot.offset {
-				bot.offset = update.UpdateID + 1
+			if update.UpdateID < bot.offset {
+				continue
+			}
+			bot.offset = update.UpdateID + 1
+
+			if bot.ackMode == AckOnComplete {
+				bot.offsetTracker.markDispatched(update.UpdateID)
 				bot.updateC <- &update
+				continue
+			}
+
+			// AckOnDispatch: only persist once the update has actually
+			// been handed off, so a crash blocked on this send can't
+			// lose it by advancing the offset past it first.
+			bot.updateC <- &update
+			if err := bot.offsetStore.Save(bot.offset); err != nil {
+				bot.errHandler(fmt.Errorf("failed to save offset, error: %w", err))
 			}
 		}
 	}
 }
 
 func (bot *Bot) Run() error {
+	// resume from the persisted offset, if any
+	offset, err := bot.offsetStore.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load offset, error: %w", err)
+	}
+	bot.offset = offset
+
 	// setup bot commands
 	if err := bot.setupCommands(); err != nil {
 		return fmt.Errorf("failed to setup commands, error: %w", err)
 	}
 
-	// start the worker
-	bot.startWorkers()
+	if bot.updateSource == nil {
+		bot.updateSource = &longPollSource{bot: bot}
+	}
+
+	updateC, err := bot.updateSource.Start(bot.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start update source, error: %w", err)
+	}
+
+	bot.startConversationReaper()
 
-	// start poll updates
-	go bot.pollUpdates()
+	// start the worker(s)
+	if bot.serializeChats {
+		bot.startSerializedWorkers(updateC)
+	} else {
+		bot.startWorkers(updateC)
+	}
 
 	// wait all worker done
 	bot.wg.Wait()
@@ -283,6 +469,12 @@ func (bot *Bot) Run() error {
 }
 
 func (bot *Bot) Stop() {
+	// let the update source drain in-flight work (e.g. webhook HTTP
+	// requests) before tearing down the workers.
+	if stopper, ok := bot.updateSource.(interface{ Stop(context.Context) error }); ok {
+		_ = stopper.Stop(context.Background())
+	}
+
 	bot.cancel()
 
 	bot.wg.Wait()
@@ -0,0 +1,139 @@
+package tgbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// WebhookConfig configures a WebhookSource.
+type WebhookConfig struct {
+	// URL is the public HTTPS address Telegram will POST updates to.
+	URL string
+	// Path is the path the standalone http.Server (see ListenAddr) serves
+	// the webhook handler on, e.g. "/webhook". URL is registered with
+	// Telegram unchanged, so it must already end in this path.
+	Path string
+	// Certificate is the self-signed certificate to upload, if any.
+	Certificate tgbotapi.RequestFileData
+	// SecretToken, when set, is required in the X-Telegram-Bot-Api-Secret-Token
+	// header of incoming requests.
+	SecretToken string
+	// AllowedUpdates restricts which update types are delivered.
+	AllowedUpdates []string
+	// MaxConnections caps Telegram's webhook connection concurrency (1-100).
+	MaxConnections int
+	// DropPendingUpdates discards any updates queued before the webhook is set.
+	DropPendingUpdates bool
+	// ListenAddr, when non-empty, makes the WebhookSource run its own
+	// http.Server on this address. Leave empty to mount
+	// Bot.WebhookHandler() on an existing http.ServeMux instead.
+	ListenAddr string
+}
+
+// WebhookSource receives updates pushed by Telegram over HTTP instead of
+// polling for them. It implements http.Handler so it can be mounted on a
+// caller-owned http.ServeMux, or it can run its own http.Server when
+// WebhookConfig.ListenAddr is set.
+type WebhookSource struct {
+	bot *Bot
+	cfg WebhookConfig
+
+	updateC chan *tgbotapi.Update
+	server  *http.Server
+}
+
+func (s *WebhookSource) Start(ctx context.Context) (<-chan *tgbotapi.Update, error) {
+	if err := s.setWebhook(); err != nil {
+		return nil, err
+	}
+
+	s.updateC = make(chan *tgbotapi.Update, s.bot.bufSize)
+
+	if s.cfg.ListenAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(s.cfg.Path, s)
+		s.server = &http.Server{Addr: s.cfg.ListenAddr, Handler: mux}
+		go func() {
+			if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.bot.errHandler(fmt.Errorf("webhook server stopped, error: %w", err))
+			}
+		}()
+	}
+
+	return s.updateC, nil
+}
+
+// setWebhook issues the raw setWebhook request. It builds the params by
+// hand, rather than going through tgbotapi.WebhookConfig, because that
+// type doesn't expose secret_token.
+func (s *WebhookSource) setWebhook() error {
+	params := tgbotapi.Params{"url": s.cfg.URL}
+	params.AddNonEmpty("secret_token", s.cfg.SecretToken)
+	params.AddNonZero("max_connections", s.cfg.MaxConnections)
+	params.AddBool("drop_pending_updates", s.cfg.DropPendingUpdates)
+	if err := params.AddInterface("allowed_updates", s.cfg.AllowedUpdates); err != nil {
+		return fmt.Errorf("failed to encode allowed updates, error: %w", err)
+	}
+
+	var resp *tgbotapi.APIResponse
+	var err error
+	if s.cfg.Certificate != nil {
+		resp, err = s.bot.api.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{
+			{Name: "certificate", Data: s.cfg.Certificate},
+		})
+	} else {
+		resp, err = s.bot.api.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set webhook, error: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("failed to set webhook: %s", resp.Description)
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler so the webhook can be mounted on the
+// caller's own http.ServeMux.
+func (s *WebhookSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.SecretToken != "" && r.Header.Get("X-Telegram-Bot-Api-Secret-Token") != s.cfg.SecretToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if s.bot.ackMode == AckOnComplete {
+		s.bot.offsetTracker.markDispatched(update.UpdateID)
+	}
+	s.updateC <- &update
+	w.WriteHeader(http.StatusOK)
+}
+
+// WebhookHandler returns the WebhookSource set up by WithWebhook, for
+// mounting on a caller-owned http.ServeMux, or nil if the bot receives
+// updates some other way.
+func (bot *Bot) WebhookHandler() http.Handler {
+	if s, ok := bot.updateSource.(*WebhookSource); ok {
+		return s
+	}
+	return nil
+}
+
+// Stop gracefully shuts down the standalone http.Server, if one was
+// started, draining in-flight requests before returning.
+func (s *WebhookSource) Stop(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,72 @@
+package tgbot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newTestWebhookSource(cfg WebhookConfig) *WebhookSource {
+	bot := &Bot{errHandler: func(err error) {}}
+	return &WebhookSource{
+		bot:     bot,
+		cfg:     cfg,
+		updateC: make(chan *tgbotapi.Update, 1),
+	}
+}
+
+func TestWebhookServeHTTPRejectsBadSecret(t *testing.T) {
+	s := newTestWebhookSource(WebhookConfig{SecretToken: "s3cr3t"})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{}`))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "wrong")
+	rec := httptest.NewRecorder()
+
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestWebhookServeHTTPAcceptsUpdate(t *testing.T) {
+	s := newTestWebhookSource(WebhookConfig{SecretToken: "s3cr3t"})
+
+	body := `{"update_id": 42}`
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "s3cr3t")
+	rec := httptest.NewRecorder()
+
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	select {
+	case update := <-s.updateC:
+		if update.UpdateID != 42 {
+			t.Fatalf("expected update_id 42, got %d", update.UpdateID)
+		}
+	default:
+		t.Fatal("expected the decoded update to be pushed to updateC")
+	}
+}
+
+func TestWebhookServeHTTPMarksDispatchedUnderAckOnComplete(t *testing.T) {
+	s := newTestWebhookSource(WebhookConfig{})
+	s.bot.ackMode = AckOnComplete
+	s.bot.offsetTracker = newOffsetTracker(newMemoryOffsetStore())
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"update_id": 7}`))
+	rec := httptest.NewRecorder()
+
+	s.ServeHTTP(rec, req)
+
+	if _, pending := s.bot.offsetTracker.pending[7]; !pending {
+		t.Fatal("expected update 7 to be tracked as dispatched")
+	}
+}